@@ -2,6 +2,7 @@ package tfawsresource
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -32,6 +33,252 @@ const (
 // the possibility you match an element you were not intending to, in the TypeSet.
 // Provide a full mapping of attributes to be sure the unique element exists.
 func TestCheckTypeSetElemNestedAttrs(name, attr string, values map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		err = testCheckTypeSetElemNestedAttrs(is, attr, values)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckTypeSetElemAttr is a resource.TestCheckFunc that accepts a resource
+// name, an attribute path, which should use the sentinel value '*' for indexing
+// into a TypeSet. The function verifies that an element matches the provided
+// value.
+//
+// Use this function over SDK provided TestCheckFunctions when validating a
+// TypeSet where its elements are a simple value
+func TestCheckTypeSetElemAttr(name, attr, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		err = testCheckTypeSetElem(is, attr, value)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckTypeSetElemAttrWith is a resource.TestCheckFunc that accepts a resource
+// name, an attribute path, which should use the sentinel value '*' for indexing
+// into a TypeSet, and a check function. The check function is called with the
+// values of the TypeSet's elements, in no particular order, until it returns
+// nil for one of them; the assertion succeeds if it does so for at least one
+// element.
+//
+// Use this function over SDK provided TestCheckFunctions when validating a
+// TypeSet where its elements are a simple value that must satisfy a structural
+// property (e.g. parses as a CIDR, matches a caller's account ID) rather than
+// an exact, known-in-advance value.
+func TestCheckTypeSetElemAttrWith(name, attr string, check func(value string) error) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		err = testCheckTypeSetElemWith(is, attr, check)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckNoTypeSetElemAttr is a resource.TestCheckFunc that accepts a resource
+// name, an attribute path, which should use the sentinel value '*' for indexing
+// into a TypeSet. The function verifies that no element matches the provided
+// value, i.e. it succeeds exactly when TestCheckTypeSetElemAttr would find no
+// matching element. A missing resource or an attribute path without the
+// sentinel is still an error.
+//
+// Use this function over iterating InstanceState.Attributes by hand when
+// asserting that a TypeSet element is absent (e.g. a security group ingress
+// rule was removed).
+func TestCheckNoTypeSetElemAttr(name, attr, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		attrParts, err := validateTypeSetElemAttr(attr)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		if typeSetElemAttrMatches(is, attrParts, value) {
+			return fmt.Errorf("%q error: TypeSet element %q, with value %q found in state: %#v", name, attr, value, is.Attributes)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckNoTypeSetElemNestedAttrs is a resource.TestCheckFunc that accepts a
+// resource name, an attribute path, which should use the sentinel value '*'
+// for indexing into a TypeSet. The function verifies that no element matches
+// the whole value map, i.e. it succeeds exactly when
+// TestCheckTypeSetElemNestedAttrs would find no matching element. A missing
+// resource, an attribute path without the sentinel or a value map with no
+// non-empty value is still an error.
+//
+// Use this function over iterating InstanceState.Attributes by hand when
+// asserting that a nested TypeSet element is absent (e.g. an IAM policy
+// statement is not present).
+func TestCheckNoTypeSetElemNestedAttrs(name, attr string, values map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		attrParts, matchCount, err := validateTypeSetElemNestedAttrs(attr, values)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		if typeSetElemNestedAttrsMatch(is, attrParts, matchCount, values) {
+			return fmt.Errorf("%q error: TypeSet element %q, with nested attrs %#v found in state: %#v", name, attr, values, is.Attributes)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckTypeSetElemAttrPair is a TestCheckFunc that verifies a pair of name/key
+// combinations are equal where the first uses the sentinel value to index into a
+// TypeSet.
+//
+// E.g., tfawsresource.TestCheckTypeSetElemAttrPair("aws_autoscaling_group.bar", "availability_zones.*", "data.aws_availability_zones.available", "names.0")
+func TestCheckTypeSetElemAttrPair(nameFirst, keyFirst, nameSecond, keySecond string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		isFirst, err := instanceState(s, nameFirst)
+		if err != nil {
+			return err
+		}
+
+		isSecond, err := instanceState(s, nameSecond)
+		if err != nil {
+			return err
+		}
+
+		vSecond, okSecond := isSecond.Attributes[keySecond]
+		if !okSecond {
+			return fmt.Errorf("%s: Attribute %q not set, cannot be checked against TypeSet", nameSecond, keySecond)
+		}
+
+		return testCheckTypeSetElem(isFirst, keyFirst, vSecond)
+	}
+}
+
+// TestCheckTypeSetElemNestedAttrsPair is a TestCheckFunc that verifies a nested
+// attrs TypeSet element on the first resource, where the sentinel value '*' is
+// used to index into the TypeSet, matches attribute values looked up on a
+// second resource.
+//
+// keySecond is the attribute path on nameSecond under which the values named
+// by attrMap are looked up, e.g. a nested block's prefix ("target_health.0");
+// pass "" if they are top-level attributes on nameSecond. For each key in
+// attrMap, its value names an attribute on nameSecond (joined with keySecond,
+// if non-empty) whose current state value is substituted in, and the
+// resulting map is matched against elements of the TypeSet on nameFirst using
+// the same algorithm as TestCheckTypeSetElemNestedAttrs.
+//
+// E.g., tfawsresource.TestCheckTypeSetElemNestedAttrsPair("aws_lb_target_group_attachment.test", "target.*", "aws_instance.test", "", map[string]string{"id": "id"})
+func TestCheckTypeSetElemNestedAttrsPair(nameFirst, keyFirst, nameSecond, keySecond string, attrMap map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		isFirst, err := instanceState(s, nameFirst)
+		if err != nil {
+			return err
+		}
+
+		isSecond, err := instanceState(s, nameSecond)
+		if err != nil {
+			return err
+		}
+
+		values := make(map[string]string, len(attrMap))
+		for keyFirstNested, keySecondAttr := range attrMap {
+			if keySecond != "" {
+				keySecondAttr = keySecond + "." + keySecondAttr
+			}
+			vSecond, okSecond := isSecond.Attributes[keySecondAttr]
+			if !okSecond {
+				return fmt.Errorf("%s: Attribute %q not set, cannot be checked against TypeSet", nameSecond, keySecondAttr)
+			}
+			values[keyFirstNested] = vSecond
+		}
+
+		err = testCheckTypeSetElemNestedAttrs(isFirst, keyFirst, values)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", nameFirst, err)
+		}
+
+		return nil
+	}
+}
+
+// TestCheckTypeSetElemCount is a resource.TestCheckFunc that accepts a resource
+// name, an attribute path, which should use the sentinel value '*' for indexing
+// into a TypeSet, and an expected count. The function verifies that exactly
+// the expected number of distinct elements exist under the attribute path.
+//
+// Use this function over reading the "%s.#" count key directly when asserting
+// the cardinality of a TypeSet (e.g. the number of ingress rules on a security
+// group), whether its elements are simple values or nested objects.
+func TestCheckTypeSetElemCount(name, attr string, expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		is, err := instanceState(s, name)
+		if err != nil {
+			return err
+		}
+
+		err = testCheckTypeSetElemCount(is, attr, expected)
+		if err != nil {
+			return fmt.Errorf("%q error: %s", name, err)
+		}
+
+		return nil
+	}
+}
+
+// TestMatchTypeSetElemNestedAttrs is a resource.TestCheckFunc that accepts a resource
+// name, an attribute path, which should use the sentinel value '*' for indexing
+// into a TypeSet. The function verifies that an element matches the whole value
+// map of regular expressions.
+//
+// A key with a nil pattern is not compared against state: it matches whether
+// the key is unset, empty or set to any value. Please provide a map with at
+// least 1 non-nil pattern.
+//
+//   map[string]*regexp.Regexp{
+//	     "key1": regexp.MustCompile("value"),
+//       "key2": nil,
+//   }
+//
+// Use this function over TestCheckTypeSetElemNestedAttrs when validating a
+// TypeSet where its elements are a nested object with values that are
+// computed or otherwise cannot be matched exactly (e.g. ARNs, generated IDs,
+// timestamps).
+//
+// Please note, if the provided value map is not granular enough, there exists
+// the possibility you match an element you were not intending to, in the TypeSet.
+// Provide a full mapping of attributes to be sure the unique element exists.
+func TestMatchTypeSetElemNestedAttrs(name, attr string, values map[string]*regexp.Regexp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		is, err := instanceState(s, name)
 		if err != nil {
@@ -48,12 +295,12 @@ func TestCheckTypeSetElemNestedAttrs(name, attr string, values map[string]string
 		// to the empty string, this will match both, which may be a false positive.
 		var matchCount int
 		for _, v := range values {
-			if v != "" {
+			if v != nil {
 				matchCount++
 			}
 		}
 		if matchCount == 0 {
-			return fmt.Errorf("%#v has no non-empty values", values)
+			return fmt.Errorf("%#v has no non-nil patterns", values)
 		}
 		for stateKey, stateValue := range is.Attributes {
 			stateKeyParts := strings.Split(stateKey, ".")
@@ -77,7 +324,7 @@ func TestCheckTypeSetElemNestedAttrs(name, attr string, values map[string]string
 			}
 			id := stateKeyParts[len(attrParts)-1]
 			nestedAttr := strings.Join(stateKeyParts[len(attrParts):], ".")
-			if v, keyExists := values[nestedAttr]; keyExists && v == stateValue {
+			if r, keyExists := values[nestedAttr]; keyExists && r != nil && r.MatchString(stateValue) {
 				matches[id] = matches[id] + 1
 				if matches[id] == matchCount {
 					return nil
@@ -85,25 +332,26 @@ func TestCheckTypeSetElemNestedAttrs(name, attr string, values map[string]string
 			}
 		}
 
-		return fmt.Errorf("%q no TypeSet element %q, with nested attrs %#v in state: %#v", name, attr, values, is.Attributes)
+		return fmt.Errorf("%q no TypeSet element %q, with nested attrs matching %#v in state: %#v", name, attr, values, is.Attributes)
 	}
 }
 
-// TestCheckTypeSetElemAttr is a resource.TestCheckFunc that accepts a resource
+// TestMatchTypeSetElemAttr is a resource.TestCheckFunc that accepts a resource
 // name, an attribute path, which should use the sentinel value '*' for indexing
 // into a TypeSet. The function verifies that an element matches the provided
-// value.
+// regular expression.
 //
-// Use this function over SDK provided TestCheckFunctions when validating a
-// TypeSet where its elements are a simple value
-func TestCheckTypeSetElemAttr(name, attr, value string) resource.TestCheckFunc {
+// Use this function over TestCheckTypeSetElemAttr when validating a TypeSet
+// where its element is a computed value not known at test authoring time
+// (e.g. ARNs, generated IDs, timestamps).
+func TestMatchTypeSetElemAttr(name, attr string, r *regexp.Regexp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		is, err := instanceState(s, name)
 		if err != nil {
 			return err
 		}
 
-		err = testCheckTypeSetElem(is, attr, value)
+		err = testMatchTypeSetElem(is, attr, r)
 		if err != nil {
 			return fmt.Errorf("%q error: %s", name, err)
 		}
@@ -112,58 +360,284 @@ func TestCheckTypeSetElemAttr(name, attr, value string) resource.TestCheckFunc {
 	}
 }
 
-// TestCheckTypeSetElemAttrPair is a TestCheckFunc that verifies a pair of name/key
-// combinations are equal where the first uses the sentinel value to index into a
-// TypeSet.
-//
-// E.g., tfawsresource.TestCheckTypeSetElemAttrPair("aws_autoscaling_group.bar", "availability_zones.*", "data.aws_availability_zones.available", "names.0")
-func TestCheckTypeSetElemAttrPair(nameFirst, keyFirst, nameSecond, keySecond string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		isFirst, err := instanceState(s, nameFirst)
-		if err != nil {
-			return err
+// instanceState returns the primary instance state for the given
+// resource name. The name may be scoped to a module instance, e.g.
+// "module.foo.module.bar.aws_thing.name", in which case it is resolved
+// within that module instead of the root module.
+func instanceState(s *terraform.State, name string) (*terraform.InstanceState, error) {
+	modulePath, resName := resourceAddress(name)
+
+	for _, mod := range s.Modules {
+		if !modulePathEqual(mod.Path, modulePath) {
+			continue
 		}
 
-		isSecond, err := instanceState(s, nameSecond)
-		if err != nil {
-			return err
+		rs, ok := mod.Resources[resName]
+		if !ok {
+			return nil, fmt.Errorf("Not found: %s in %s", resName, mod.Path)
 		}
 
-		vSecond, okSecond := isSecond.Attributes[keySecond]
-		if !okSecond {
-			return fmt.Errorf("%s: Attribute %q not set, cannot be checked against TypeSet", nameSecond, keySecond)
+		is := rs.Primary
+		if is == nil {
+			return nil, fmt.Errorf("No primary instance: %s in %s", resName, mod.Path)
 		}
 
-		return testCheckTypeSetElem(isFirst, keyFirst, vSecond)
+		return is, nil
 	}
+
+	return nil, fmt.Errorf("No module found: %s", strings.Join(modulePath, "."))
 }
 
-// instanceState returns the primary instance state for the given
-// resource name in the root module.
-func instanceState(s *terraform.State, name string) (*terraform.InstanceState, error) {
-	ms := s.RootModule()
-	rs, ok := ms.Resources[name]
-	if !ok {
-		return nil, fmt.Errorf("Not found: %s in %s", name, ms.Path)
+// resourceAddress splits a resource address of the form
+// "module.foo.module.bar.aws_thing.name" into the module path ("foo", "bar")
+// and the resource name within that module ("aws_thing.name"), as compared
+// against ModuleState.Path by modulePathEqual. Addresses with no "module."
+// prefix resolve to an empty module path, i.e. the root module.
+func resourceAddress(name string) ([]string, string) {
+	var path []string
+	parts := strings.Split(name, ".")
+
+	i := 0
+	for i+1 < len(parts) && parts[i] == "module" {
+		path = append(path, parts[i+1])
+		i += 2
+	}
+
+	return path, strings.Join(parts[i:], ".")
+}
+
+// modulePathEqual compares two module paths, dropping the leading "root"
+// element of the legacy representation (ModuleState.Path == []string{"root"}
+// for the root module, []string{"root", "foo", "bar"} for module.foo.module.bar)
+// so that it compares equal to the path produced by resourceAddress.
+func modulePathEqual(a, b []string) bool {
+	if len(a) > 0 && a[0] == "root" {
+		a = a[1:]
+	}
+	if len(b) > 0 && b[0] == "root" {
+		b = b[1:]
+	}
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
 
-	is := rs.Primary
-	if is == nil {
-		return nil, fmt.Errorf("No primary instance: %s in %s", name, ms.Path)
+	return true
+}
+
+// validateTypeSetElemAttr validates that attr ends with the sentinel index,
+// returning its parsed, dot-separated parts. This is pure input validation,
+// kept separate from the found/not-found result of a search so that
+// negative assertions (e.g. TestCheckNoTypeSetElemAttr) can still surface
+// misuse as a real error instead of a false "not found".
+func validateTypeSetElemAttr(attr string) ([]string, error) {
+	attrParts := strings.Split(attr, ".")
+	if attrParts[len(attrParts)-1] != sentinelIndex {
+		return nil, fmt.Errorf("%q does not end with the special value %q", attr, sentinelIndex)
+	}
+
+	return attrParts, nil
+}
+
+func typeSetElemAttrMatches(is *terraform.InstanceState, attrParts []string, value string) bool {
+	for stateKey, stateValue := range is.Attributes {
+		if stateValue == value {
+			stateKeyParts := strings.Split(stateKey, ".")
+			if len(stateKeyParts) == len(attrParts) && !isTypeSetMetaKey(stateKeyParts[len(attrParts)-1]) {
+				for i := range attrParts {
+					if attrParts[i] != stateKeyParts[i] && attrParts[i] != sentinelIndex {
+						break
+					}
+					if i == len(attrParts)-1 {
+						return true
+					}
+				}
+			}
+		}
 	}
 
-	return is, nil
+	return false
+}
+
+// isTypeSetMetaKey reports whether id is a flatmap metadata key ("#" for a
+// Set/List count, "%" for a Map length) rather than a real element index, so
+// walkers indexing on the sentinel don't present it as a candidate element.
+func isTypeSetMetaKey(id string) bool {
+	return id == "#" || id == "%"
 }
 
 func testCheckTypeSetElem(is *terraform.InstanceState, attr, value string) error {
+	attrParts, err := validateTypeSetElemAttr(attr)
+	if err != nil {
+		return err
+	}
+
+	if typeSetElemAttrMatches(is, attrParts, value) {
+		return nil
+	}
+
+	return fmt.Errorf("no TypeSet element %q, with value %q in state: %#v", attr, value, is.Attributes)
+}
+
+// validateTypeSetElemNestedAttrs validates that attr ends with the sentinel
+// index and that values has at least 1 non-empty value, returning the
+// parsed attr parts and the number of non-empty values to match. This is
+// pure input validation, kept separate from the found/not-found result of a
+// search so that negative assertions (e.g. TestCheckNoTypeSetElemNestedAttrs)
+// can still surface misuse as a real error instead of a false "not found".
+func validateTypeSetElemNestedAttrs(attr string, values map[string]string) ([]string, int, error) {
+	attrParts := strings.Split(attr, ".")
+	if attrParts[len(attrParts)-1] != sentinelIndex {
+		return nil, 0, fmt.Errorf("%q does not end with the special value %q", attr, sentinelIndex)
+	}
+
+	// account for cases where the user is trying to see if the value is unset/empty
+	// there may be ambiguous scenarios where a field was deliberately unset vs set
+	// to the empty string, this will match both, which may be a false positive.
+	var matchCount int
+	for _, v := range values {
+		if v != "" {
+			matchCount++
+		}
+	}
+	if matchCount == 0 {
+		return nil, 0, fmt.Errorf("%#v has no non-empty values", values)
+	}
+
+	return attrParts, matchCount, nil
+}
+
+func typeSetElemNestedAttrsMatch(is *terraform.InstanceState, attrParts []string, matchCount int, values map[string]string) bool {
+	matches := make(map[string]int)
+	for stateKey, stateValue := range is.Attributes {
+		stateKeyParts := strings.Split(stateKey, ".")
+		// a Set/List item with nested attrs would have a flatmap address of
+		// at least length 3
+		// foo.0.name = "bar"
+		if len(stateKeyParts) < 3 {
+			continue
+		}
+		var pathMatch bool
+		for i := range attrParts {
+			if attrParts[i] != stateKeyParts[i] && attrParts[i] != sentinelIndex {
+				break
+			}
+			if i == len(attrParts)-1 {
+				pathMatch = true
+			}
+		}
+		if !pathMatch {
+			continue
+		}
+		id := stateKeyParts[len(attrParts)-1]
+		nestedAttr := strings.Join(stateKeyParts[len(attrParts):], ".")
+		if v, keyExists := values[nestedAttr]; keyExists && v == stateValue {
+			matches[id] = matches[id] + 1
+			if matches[id] == matchCount {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func testCheckTypeSetElemNestedAttrs(is *terraform.InstanceState, attr string, values map[string]string) error {
+	attrParts, matchCount, err := validateTypeSetElemNestedAttrs(attr, values)
+	if err != nil {
+		return err
+	}
+
+	if typeSetElemNestedAttrsMatch(is, attrParts, matchCount, values) {
+		return nil
+	}
+
+	return fmt.Errorf("no TypeSet element %q, with nested attrs %#v in state: %#v", attr, values, is.Attributes)
+}
+
+func testCheckTypeSetElemCount(is *terraform.InstanceState, attr string, expected int) error {
+	attrParts := strings.Split(attr, ".")
+	if attrParts[len(attrParts)-1] != sentinelIndex {
+		return fmt.Errorf("%q does not end with the special value %q", attr, sentinelIndex)
+	}
+	ids := make(map[string]bool)
+	for stateKey := range is.Attributes {
+		stateKeyParts := strings.Split(stateKey, ".")
+		if len(stateKeyParts) < len(attrParts) {
+			continue
+		}
+		var pathMatch bool
+		for i := range attrParts {
+			if attrParts[i] != stateKeyParts[i] && attrParts[i] != sentinelIndex {
+				break
+			}
+			if i == len(attrParts)-1 {
+				pathMatch = true
+			}
+		}
+		if !pathMatch {
+			continue
+		}
+		id := stateKeyParts[len(attrParts)-1]
+		// skip the flatmap count/map-length keys ("foo.#", "foo.%"), which
+		// would otherwise be counted as an element index under the sentinel.
+		if id == "#" || id == "%" {
+			continue
+		}
+		ids[id] = true
+	}
+
+	if len(ids) != expected {
+		return fmt.Errorf("%q expected %d TypeSet elements, found %d in state: %#v", attr, expected, len(ids), is.Attributes)
+	}
+
+	return nil
+}
+
+func testCheckTypeSetElemWith(is *terraform.InstanceState, attr string, check func(value string) error) error {
 	attrParts := strings.Split(attr, ".")
 	if attrParts[len(attrParts)-1] != sentinelIndex {
 		return fmt.Errorf("%q does not end with the special value %q", attr, sentinelIndex)
 	}
 	for stateKey, stateValue := range is.Attributes {
-		if stateValue == value {
+		stateKeyParts := strings.Split(stateKey, ".")
+		if len(stateKeyParts) != len(attrParts) || isTypeSetMetaKey(stateKeyParts[len(attrParts)-1]) {
+			continue
+		}
+		var pathMatch bool
+		for i := range attrParts {
+			if attrParts[i] != stateKeyParts[i] && attrParts[i] != sentinelIndex {
+				break
+			}
+			if i == len(attrParts)-1 {
+				pathMatch = true
+			}
+		}
+		if !pathMatch {
+			continue
+		}
+		if err := check(stateValue); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no TypeSet element %q, with value matching the check function in state: %#v", attr, is.Attributes)
+}
+
+func testMatchTypeSetElem(is *terraform.InstanceState, attr string, r *regexp.Regexp) error {
+	attrParts := strings.Split(attr, ".")
+	if attrParts[len(attrParts)-1] != sentinelIndex {
+		return fmt.Errorf("%q does not end with the special value %q", attr, sentinelIndex)
+	}
+	for stateKey, stateValue := range is.Attributes {
+		if r.MatchString(stateValue) {
 			stateKeyParts := strings.Split(stateKey, ".")
-			if len(stateKeyParts) == len(attrParts) {
+			if len(stateKeyParts) == len(attrParts) && !isTypeSetMetaKey(stateKeyParts[len(attrParts)-1]) {
 				for i := range attrParts {
 					if attrParts[i] != stateKeyParts[i] && attrParts[i] != sentinelIndex {
 						break
@@ -176,5 +650,5 @@ func testCheckTypeSetElem(is *terraform.InstanceState, attr, value string) error
 		}
 	}
 
-	return fmt.Errorf("no TypeSet element %q, with value %q in state: %#v", attr, value, is.Attributes)
+	return fmt.Errorf("no TypeSet element %q, matching %q in state: %#v", attr, r.String(), is.Attributes)
 }